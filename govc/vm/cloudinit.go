@@ -0,0 +1,265 @@
+/*
+Copyright (c) 2024 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vmware/govmomi/object"
+)
+
+// cloudInitInput resolves a -user-data/-meta-data/-network-config flag value.
+// A "@path" value is read from the given file, anything else is used as-is.
+// An empty value returns a nil slice so callers can fall back to synthesized
+// content.
+func cloudInitInput(value string) ([]byte, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(value, "@") {
+		path := strings.TrimPrefix(value, "@")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %q: %s", path, err)
+		}
+		return data, nil
+	}
+
+	return []byte(value), nil
+}
+
+// cloudInitNetworkConfig synthesizes a cloud-init network-config v2 document
+// from the same per-NIC flags used for VMware Tools GOSC (-ip, -netmask,
+// -gateway, -mac, -ip6, -gateway6, -dns-server). Each NIC needs a way for the
+// guest to match it to a netplan "ethernets" entry: by MAC address when
+// -mac is given, or by a predictable-interface-name glob when there is
+// exactly one NIC. With more than one NIC and no -mac, there is no reliable
+// way to tell them apart, so that combination is rejected rather than
+// silently emitting a stanza that matches nothing (or the wrong NIC).
+func (cmd *customize) cloudInitNetworkConfig() (map[string]any, error) {
+	nics := len(cmd.ip)
+	if len(cmd.ip6) > nics {
+		nics = len(cmd.ip6)
+	}
+	if nics == 0 {
+		return nil, nil
+	}
+	if nics > 1 && len(cmd.mac) < nics {
+		return nil, fmt.Errorf("-cloud-init requires -mac for each NIC when customizing more than one NIC")
+	}
+
+	ethernets := make(map[string]any, nics)
+
+	for i := 0; i < nics; i++ {
+		name := fmt.Sprintf("id%d", i)
+		eth := map[string]any{}
+
+		if i < len(cmd.mac) {
+			eth["match"] = map[string]any{"macaddress": cmd.mac[i]}
+			eth["set-name"] = name
+		} else {
+			// Single NIC, no -mac: match on the predictable interface name
+			// assigned by systemd/udev to the first (and only) NIC.
+			eth["match"] = map[string]any{"name": "en*"}
+		}
+
+		var addresses []string
+		if i < len(cmd.ip) {
+			switch cmd.ip[i] {
+			case "dhcp":
+				eth["dhcp4"] = true
+			default:
+				addresses = append(addresses, cidr(cmd.ip[i], i, cmd.netmask))
+			}
+		}
+		if i < len(cmd.ip6) {
+			switch cmd.ip6[i] {
+			case "dhcp":
+				eth["dhcp6"] = true
+			case "slaac":
+				// SLAAC is the default when dhcp6 is unset and
+				// router advertisements are present, nothing to set.
+			default:
+				addresses = append(addresses, cmd.ip6[i])
+			}
+		}
+		if len(addresses) > 0 {
+			eth["addresses"] = addresses
+		}
+
+		if i < len(cmd.gateway) {
+			eth["gateway4"] = strings.Split(cmd.gateway[i], ",")[0]
+		}
+		if i < len(cmd.gateway6) {
+			eth["gateway6"] = strings.Split(cmd.gateway6[i], ",")[0]
+		}
+
+		if len(cmd.dnsserver) > 0 {
+			eth["nameservers"] = map[string]any{"addresses": splitAll(cmd.dnsserver)}
+		}
+
+		ethernets[name] = eth
+	}
+
+	return map[string]any{
+		"version":   2,
+		"ethernets": ethernets,
+	}, nil
+}
+
+func splitAll(values []string) []string {
+	var out []string
+	for _, v := range values {
+		out = append(out, strings.Split(v, ",")...)
+	}
+	return out
+}
+
+// cidr appends the netmask at index i (if any) to a static IPv4 address,
+// converting it to a CIDR prefix length. The address is returned without a
+// prefix if there is no netmask at index i or it isn't a dotted-quad.
+func cidr(ip string, i int, netmask []string) string {
+	if i >= len(netmask) {
+		return ip
+	}
+
+	prefix := maskToPrefix(netmask[i])
+	if prefix < 0 {
+		return ip
+	}
+
+	return fmt.Sprintf("%s/%d", ip, prefix)
+}
+
+func maskToPrefix(mask string) int {
+	octets := strings.Split(mask, ".")
+	if len(octets) != 4 {
+		return -1
+	}
+
+	prefix := 0
+	for _, o := range octets {
+		var b int
+		if _, err := fmt.Sscanf(o, "%d", &b); err != nil || b < 0 || b > 255 {
+			return -1
+		}
+		for b > 0 {
+			prefix += b & 1
+			b >>= 1
+		}
+	}
+
+	return prefix
+}
+
+// cloudInitInstanceID returns a random per-invocation instance-id. cloud-init
+// keys its "already customized this instance" semaphore off of instance-id,
+// so every vm.customize -cloud-init run (including a second run against the
+// same VM, e.g. after a template re-clone) needs a fresh value - a constant
+// would cause cloud-init to silently skip re-applying user-data/network-config
+// on second and later boots.
+func cloudInitInstanceID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("govc-%x", b), nil
+}
+
+// cloudInitMetaData synthesizes a minimal NoCloud meta-data document. If
+// networkDoc is given (a synthesized network-config v2 document), it is
+// embedded directly under the "network" key, as the VMware GuestInfo
+// cloud-init datasource expects. Otherwise, if networkConfigRaw is given
+// (arbitrary user-supplied content, e.g. from -network-config @file), it is
+// embedded as a base64 string instead, since it may not be representable as
+// a JSON value (or even be valid YAML/JSON at all).
+func cloudInitMetaData(hostname string, networkDoc map[string]any, networkConfigRaw []byte) ([]byte, error) {
+	instanceID, err := cloudInitInstanceID()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := map[string]any{
+		"instance-id": instanceID,
+	}
+
+	if hostname != "" {
+		doc["local-hostname"] = hostname
+	}
+
+	switch {
+	case networkDoc != nil:
+		doc["network"] = networkDoc
+	case len(networkConfigRaw) > 0:
+		doc["network"] = base64.StdEncoding.EncodeToString(networkConfigRaw)
+		doc["network.encoding"] = "base64"
+	}
+
+	return json.Marshal(doc)
+}
+
+// runCloudInit implements -cloud-init: it builds (or reads) a NoCloud
+// user-data/meta-data/network-config payload and injects it into the VM's
+// guestinfo namespace instead of issuing a VirtualMachine.Customize call.
+func (cmd *customize) runCloudInit(ctx context.Context, vm *object.VirtualMachine) error {
+	userData, err := cloudInitInput(cmd.userData)
+	if err != nil {
+		return err
+	}
+
+	networkConfigRaw, err := cloudInitInput(cmd.networkConfig)
+	if err != nil {
+		return err
+	}
+
+	var networkDoc map[string]any
+	if networkConfigRaw == nil {
+		networkDoc, err = cmd.cloudInitNetworkConfig()
+		if err != nil {
+			return err
+		}
+	}
+
+	metaData, err := cloudInitInput(cmd.metaData)
+	if err != nil {
+		return err
+	}
+	if metaData == nil {
+		metaData, err = cloudInitMetaData(cmd.host.Name, networkDoc, networkConfigRaw)
+		if err != nil {
+			return err
+		}
+	}
+
+	if userData == nil {
+		userData = []byte("#cloud-config\n{}\n")
+	}
+
+	task, err := vm.SetCloudInitGuestInfo(ctx, userData, metaData)
+	if err != nil {
+		return err
+	}
+
+	return task.Wait(ctx)
+}
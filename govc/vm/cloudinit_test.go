@@ -0,0 +1,124 @@
+/*
+Copyright (c) 2024 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vm
+
+import "testing"
+
+func TestMaskToPrefix(t *testing.T) {
+	tests := []struct {
+		mask string
+		want int
+	}{
+		{"255.255.255.0", 24},
+		{"255.255.0.0", 16},
+		{"255.0.0.0", 8},
+		{"255.255.255.255", 32},
+		{"0.0.0.0", 0},
+		{"not-a-mask", -1},
+		{"255.255.255", -1},
+	}
+
+	for _, test := range tests {
+		if got := maskToPrefix(test.mask); got != test.want {
+			t.Errorf("maskToPrefix(%q) = %d, want %d", test.mask, got, test.want)
+		}
+	}
+}
+
+func TestCidr(t *testing.T) {
+	tests := []struct {
+		ip      string
+		i       int
+		netmask []string
+		want    string
+	}{
+		{"10.0.0.178", 0, []string{"255.255.255.0"}, "10.0.0.178/24"},
+		{"10.0.0.178", 1, []string{"255.255.255.0"}, "10.0.0.178"},   // no netmask at this index
+		{"10.0.0.178", 0, []string{"not-a-mask"}, "10.0.0.178"},      // unparsable netmask
+		{"10.0.0.178", 0, nil, "10.0.0.178"},
+	}
+
+	for _, test := range tests {
+		if got := cidr(test.ip, test.i, test.netmask); got != test.want {
+			t.Errorf("cidr(%q, %d, %v) = %q, want %q", test.ip, test.i, test.netmask, got, test.want)
+		}
+	}
+}
+
+func TestCloudInitNetworkConfig(t *testing.T) {
+	t.Run("single NIC without -mac matches by interface name", func(t *testing.T) {
+		cmd := &customize{}
+		cmd.ip = []string{"10.0.0.178"}
+		cmd.netmask = []string{"255.255.255.0"}
+		cmd.gateway = []string{"10.0.0.1"}
+
+		doc, err := cmd.cloudInitNetworkConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		eth := doc["ethernets"].(map[string]any)["id0"].(map[string]any)
+		match, ok := eth["match"].(map[string]any)
+		if !ok {
+			t.Fatalf("expected a match clause, got %#v", eth)
+		}
+		if _, ok := match["macaddress"]; ok {
+			t.Errorf("expected no macaddress match without -mac, got %#v", match)
+		}
+		if match["name"] == "" {
+			t.Errorf("expected a non-empty interface-name match, got %#v", match)
+		}
+	})
+
+	t.Run("NIC with -mac matches by MAC address", func(t *testing.T) {
+		cmd := &customize{}
+		cmd.ip = []string{"10.0.0.178"}
+		cmd.mac = []string{"00:50:56:be:dd:f8"}
+
+		doc, err := cmd.cloudInitNetworkConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		eth := doc["ethernets"].(map[string]any)["id0"].(map[string]any)
+		match := eth["match"].(map[string]any)
+		if match["macaddress"] != "00:50:56:be:dd:f8" {
+			t.Errorf("match = %#v, want macaddress 00:50:56:be:dd:f8", match)
+		}
+	})
+
+	t.Run("multiple NICs without -mac is rejected", func(t *testing.T) {
+		cmd := &customize{}
+		cmd.ip = []string{"10.0.0.178", "10.0.0.162"}
+
+		if _, err := cmd.cloudInitNetworkConfig(); err == nil {
+			t.Error("expected an error, got nil")
+		}
+	})
+
+	t.Run("no NICs requested returns a nil document", func(t *testing.T) {
+		cmd := &customize{}
+
+		doc, err := cmd.cloudInitNetworkConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc != nil {
+			t.Errorf("expected a nil document, got %#v", doc)
+		}
+	})
+}
@@ -20,8 +20,10 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/vmware/govmomi/govc/cli"
 	"github.com/vmware/govmomi/govc/flags"
@@ -43,6 +45,35 @@ type customize struct {
 	netmask   flags.StringList
 	dnsserver flags.StringList
 	kind      string
+
+	ip6       flags.StringList
+	gateway6  flags.StringList
+	dnsSuffix flags.StringList
+
+	specFile string
+	dump     string
+
+	cloudInit     bool
+	userData      string
+	metaData      string
+	networkConfig string
+
+	wait    bool
+	timeout time.Duration
+
+	productKey         string
+	adminPassword      string
+	adminPasswordPlain string
+	orgName            string
+	fullName           string
+	workgroup          string
+	domainUsername     string
+	domainPassword     string
+	domainOU           string
+	runOnce            flags.StringList
+	guiLocale          string
+	guiLanguage        string
+	hwClockUTC         bool
 }
 
 func init() {
@@ -68,7 +99,39 @@ func (cmd *customize) Register(ctx context.Context, f *flag.FlagSet) {
 	cmd.netmask = nil
 	f.Var(&cmd.dnsserver, "dns-server", "DNS server")
 	cmd.dnsserver = nil
+	f.Var(&cmd.ip6, "ip6", "IPv6 address, one of: dhcp, slaac or address/prefix")
+	cmd.ip6 = nil
+	f.Var(&cmd.gateway6, "gateway6", "IPv6 Gateway")
+	cmd.gateway6 = nil
+	f.Var(&cmd.dnsSuffix, "dns-suffix", "DNS search domain")
+	cmd.dnsSuffix = nil
 	f.StringVar(&cmd.kind, "type", "Linux", "Customization type if spec NAME is not specified (Linux|Windows)")
+
+	f.StringVar(&cmd.productKey, "product-key", "", "Windows product key")
+	f.StringVar(&cmd.adminPassword, "admin-password", "", "Windows Administrator password, already encrypted")
+	f.StringVar(&cmd.adminPasswordPlain, "admin-password-plain", "", "Windows Administrator password, as plain text")
+	f.StringVar(&cmd.orgName, "org-name", "", "Windows organization name")
+	f.StringVar(&cmd.fullName, "full-name", "", "Windows registered user full name")
+	f.StringVar(&cmd.workgroup, "workgroup", "", "Windows workgroup name")
+	f.StringVar(&cmd.domainUsername, "domain-username", "", "Username with privileges to join -domain")
+	f.StringVar(&cmd.domainPassword, "domain-password", "", "Password for -domain-username")
+	f.StringVar(&cmd.domainOU, "domain-ou", "", "Organizational unit (LDAP path) to join within -domain, requires vSphere 8.0.2 or later")
+	f.Var(&cmd.runOnce, "run-once", "Command to run at first user logon, may be specified multiple times")
+	cmd.runOnce = nil
+	f.StringVar(&cmd.guiLocale, "gui-locale", "", "GUI unattended locale")
+	f.StringVar(&cmd.guiLanguage, "gui-language", "", "GUI unattended language")
+	f.BoolVar(&cmd.hwClockUTC, "hw-clock-utc", false, "Linux only, specify that the hardware clock is in UTC")
+
+	f.StringVar(&cmd.specFile, "spec-file", "", "Path to a JSON CustomizationSpec document")
+	f.StringVar(&cmd.dump, "dump", "", "Serialize the resolved spec as json instead of applying it")
+
+	f.BoolVar(&cmd.cloudInit, "cloud-init", false, "Customize via cloud-init guestinfo instead of VMware Tools GOSC")
+	f.StringVar(&cmd.userData, "user-data", "", "cloud-init user-data, or @FILE to read from a file")
+	f.StringVar(&cmd.metaData, "meta-data", "", "cloud-init meta-data, or @FILE to read from a file")
+	f.StringVar(&cmd.networkConfig, "network-config", "", "cloud-init network-config, or @FILE to read from a file")
+
+	f.BoolVar(&cmd.wait, "wait", false, "Wait for in-guest customization to complete, via the VM's event stream")
+	f.DurationVar(&cmd.timeout, "timeout", 10*time.Minute, "Timeout for -wait")
 }
 
 func (cmd *customize) Usage() string {
@@ -83,8 +146,52 @@ Optionally specify a customization spec NAME.
 The '-ip', '-netmask' and '-gateway' flags are for static IP configuration.
 If the VM has multiple NICs, an '-ip' and '-netmask' must be specified for each.
 
+The '-ip6' flag configures IPv6 on a NIC, correlated by index (or by '-mac' when given) with
+any '-ip' flags given for the same NIC. The value is one of "dhcp", "slaac" or a static
+"address/prefix", for example "2001:db8::10/64". '-gateway6' sets the IPv6 gateway(s) for the
+same NIC. '-dns-suffix' appends to the DNS search domain list and may be specified multiple
+times.
+
 Windows -tz value requires the Index (hex): https://support.microsoft.com/en-us/help/973627/microsoft-time-zone-index-values
 
+The '-product-key', '-admin-password' (or '-admin-password-plain'), '-org-name', '-full-name',
+'-workgroup', '-domain-username', '-domain-password', '-domain-ou' and '-run-once' flags are
+Windows only, for use with Sysprep. '-workgroup' and '-domain' are mutually exclusive.
+
+'-gui-locale' and '-gui-language' are not representable via the structured CustomizationSysprep
+API, so giving either flag is an error. A locale or language can only be set in a raw Sysprep
+answer file ('sysprepText' in a '-spec-file' document).
+
+The '-hw-clock-utc' flag is Linux only.
+
+'-spec-file' loads a full CustomizationSpec from a JSON document instead of building one from
+NAME or the flags above; any other customization flags given on the command line (such as
+'-ip' or '-name') are applied as overrides on top of the loaded spec. '-spec-file' and NAME are
+mutually exclusive. The document has 'globalIPSettings', 'identity' (one of 'linuxPrep',
+'sysprep' or a raw 'sysprepText' Sysprep answer file), 'nicSettingMap' and 'encryptionKey' keys;
+'linuxPrep' and 'sysprep' are plain camelCase objects (e.g. 'hostName', 'joinDomain',
+'adminPassword'), not a direct rendering of the vSphere API's CustomizationLinuxPrep/CustomizationSysprep
+types. An omitted 'hostName' or 'computerName' uses the VM's name. The document has no place for
+IPv6 NIC settings, spec options or a '-prefix' host name generator, so '-dump' leaves those out.
+
+'-dump' serializes the resolved spec back to the same JSON shape on stdout instead of applying
+it, for round-tripping with '-spec-file' or diffing against a spec stored in vCenter.
+
+'-cloud-init' customizes the VM via a cloud-init NoCloud payload over guestinfo instead of
+VMware Tools GOSC, for guests that do not ship the legacy perl-based customization scripts
+(Ubuntu cloud images, Photon OS, etc). '-user-data', '-meta-data' and '-network-config' accept
+literal content or '@FILE'. An omitted network-config is synthesized from '-ip', '-netmask',
+'-gateway', '-mac', '-ip6', '-gateway6' and '-dns-server', an omitted meta-data from '-name' and
+the network-config, and an omitted user-data is an empty '#cloud-config' document. A given
+'-meta-data' is used as-is, and the network-config (given or synthesized) is then not passed to
+the guest. '-cloud-init' does not use NAME, a
+customization spec, '-spec-file' or any Windows Sysprep flag.
+
+'-wait' blocks after the customization task completes until the VM's event stream reports
+CustomizationSucceeded or CustomizationFailed, confirming that in-guest customization actually
+ran rather than only that vCenter accepted the request. '-timeout' bounds how long to wait
+(default 10m). '-wait' only applies to VMware Tools GOSC, not '-cloud-init'.
+
 Examples:
   govc vm.customize -vm VM NAME
   govc vm.customize -vm VM -name my-hostname -ip dhcp
@@ -95,7 +202,18 @@ Examples:
   govc vm.customize -vm VM -mac 00:50:56:be:dd:f8 -ip 10.0.0.178 -netmask 255.255.255.0 -mac 00:50:56:be:60:cf -ip 10.0.0.162 -netmask 255.255.255.0
   govc vm.customize -vm VM -auto-login 3 NAME
   govc vm.customize -vm VM -prefix demo NAME
-  govc vm.customize -vm VM -tz America/New_York NAME`
+  govc vm.customize -vm VM -tz America/New_York NAME
+  govc vm.customize -vm VM -type Windows -product-key XXXXX-XXXXX-XXXXX-XXXXX-XXXXX -admin-password-plain s3cr3t NAME
+  govc vm.customize -vm VM -type Windows -domain example.com -domain-username admin -domain-password s3cr3t -domain-ou "OU=Workstations,DC=example,DC=com" NAME
+  govc vm.customize -vm VM -type Windows -run-once "cmd.exe /c dir" -run-once "cmd.exe /c echo done" NAME
+  govc vm.customize -vm VM -ip6 dhcp NAME
+  govc vm.customize -vm VM -ip6 2001:db8::10/64 -gateway6 2001:db8::1 -dns-suffix example.com NAME
+  govc vm.customize -vm VM -spec-file spec.json
+  govc vm.customize -vm VM -spec-file spec.json -ip 10.0.0.178
+  govc vm.customize -vm VM -dump json NAME
+  govc vm.customize -vm VM -cloud-init -name my-hostname -ip 10.0.0.178 -netmask 255.255.255.0 -gateway 10.0.0.1
+  govc vm.customize -vm VM -cloud-init -user-data @user-data.yaml -network-config @network-config.yaml
+  govc vm.customize -vm VM -wait -timeout 20m NAME`
 }
 
 func (cmd *customize) Run(ctx context.Context, f *flag.FlagSet) error {
@@ -108,12 +226,30 @@ func (cmd *customize) Run(ctx context.Context, f *flag.FlagSet) error {
 		return flag.ErrHelp
 	}
 
+	if cmd.cloudInit {
+		return cmd.runCloudInit(ctx, vm)
+	}
+
 	var spec *types.CustomizationSpec
 
 	name := f.Arg(0)
-	if name == "" {
+	switch {
+	case cmd.specFile != "":
+		if name != "" {
+			return fmt.Errorf("'-spec-file' and NAME are mutually exclusive")
+		}
+
+		spec, err = loadCustomizationSpecFile(cmd.specFile)
+		if err != nil {
+			return err
+		}
+	case name == "":
+		nics := len(cmd.ip)
+		if len(cmd.ip6) > nics {
+			nics = len(cmd.ip6)
+		}
 		spec = &types.CustomizationSpec{
-			NicSettingMap: make([]types.CustomizationAdapterMapping, len(cmd.ip)),
+			NicSettingMap: make([]types.CustomizationAdapterMapping, nics),
 		}
 
 		switch cmd.kind {
@@ -130,7 +266,7 @@ func (cmd *customize) Run(ctx context.Context, f *flag.FlagSet) error {
 		default:
 			return flag.ErrHelp
 		}
-	} else {
+	default:
 		m := object.NewCustomizationSpecManager(vm.Client())
 
 		exists, err := m.DoesCustomizationSpecExist(ctx, name)
@@ -153,9 +289,19 @@ func (cmd *customize) Run(ctx context.Context, f *flag.FlagSet) error {
 		return fmt.Errorf("%d -ip specified, spec %q has %d", len(cmd.ip), name, len(spec.NicSettingMap))
 	}
 
+	if len(cmd.ip6) > len(spec.NicSettingMap) {
+		return fmt.Errorf("%d -ip6 specified, spec %q has %d", len(cmd.ip6), name, len(spec.NicSettingMap))
+	}
+
 	sysprep, isWindows := spec.Identity.(*types.CustomizationSysprep)
 	linprep, _ := spec.Identity.(*types.CustomizationLinuxPrep)
 
+	if !isWindows && linprep == nil {
+		if cmd.domain != "" || cmd.prefix.Base != "" || cmd.host.Name != "" || cmd.tz != "" || cmd.hwClockUTC {
+			return fmt.Errorf("spec %q identity is neither Sysprep nor LinuxPrep, cannot apply -domain/-prefix/-name/-tz/-hw-clock-utc", name)
+		}
+	}
+
 	if cmd.domain != "" {
 		if isWindows {
 			sysprep.Identification.JoinDomain = cmd.domain
@@ -173,6 +319,10 @@ func (cmd *customize) Run(ctx context.Context, f *flag.FlagSet) error {
 		}
 	}
 
+	for _, s := range cmd.dnsSuffix {
+		spec.GlobalIPSettings.DnsSuffixList = append(spec.GlobalIPSettings.DnsSuffixList, strings.Split(s, ",")...)
+	}
+
 	if cmd.prefix.Base != "" {
 		if isWindows {
 			sysprep.UserData.ComputerName = &cmd.prefix
@@ -197,6 +347,97 @@ func (cmd *customize) Run(ctx context.Context, f *flag.FlagSet) error {
 		sysprep.GuiUnattended.AutoLogonCount = int32(cmd.alc)
 	}
 
+	if cmd.productKey != "" {
+		if !isWindows {
+			return fmt.Errorf("option '-product-key' is Windows only")
+		}
+		sysprep.UserData.ProductId = cmd.productKey
+	}
+
+	if cmd.adminPassword != "" || cmd.adminPasswordPlain != "" {
+		if !isWindows {
+			return fmt.Errorf("option '-admin-password' is Windows only")
+		}
+		if cmd.adminPassword != "" && cmd.adminPasswordPlain != "" {
+			return fmt.Errorf("options '-admin-password' and '-admin-password-plain' are mutually exclusive")
+		}
+		password := &types.CustomizationPassword{Value: cmd.adminPassword}
+		if cmd.adminPasswordPlain != "" {
+			password.Value = cmd.adminPasswordPlain
+			password.PlainText = true
+		}
+		sysprep.GuiUnattended.Password = password
+	}
+
+	if cmd.orgName != "" {
+		if !isWindows {
+			return fmt.Errorf("option '-org-name' is Windows only")
+		}
+		sysprep.UserData.OrgName = cmd.orgName
+	}
+
+	if cmd.fullName != "" {
+		if !isWindows {
+			return fmt.Errorf("option '-full-name' is Windows only")
+		}
+		sysprep.UserData.FullName = cmd.fullName
+	}
+
+	if cmd.workgroup != "" {
+		if !isWindows {
+			return fmt.Errorf("option '-workgroup' is Windows only")
+		}
+		if cmd.domain != "" {
+			return fmt.Errorf("options '-workgroup' and '-domain' are mutually exclusive")
+		}
+		sysprep.Identification.JoinWorkgroup = cmd.workgroup
+	}
+
+	if cmd.domainUsername != "" {
+		if !isWindows {
+			return fmt.Errorf("option '-domain-username' is Windows only")
+		}
+		sysprep.Identification.DomainAdmin = cmd.domainUsername
+	}
+
+	if cmd.domainPassword != "" {
+		if !isWindows {
+			return fmt.Errorf("option '-domain-password' is Windows only")
+		}
+		sysprep.Identification.DomainAdminPassword = &types.CustomizationPassword{
+			Value:     cmd.domainPassword,
+			PlainText: true,
+		}
+	}
+
+	if cmd.domainOU != "" {
+		if !isWindows {
+			return fmt.Errorf("option '-domain-ou' is Windows only")
+		}
+		sysprep.Identification.DomainAdminOU = cmd.domainOU
+	}
+
+	if len(cmd.runOnce) != 0 {
+		if !isWindows {
+			return fmt.Errorf("option '-run-once' is Windows only")
+		}
+		sysprep.GuiRunOnce = &types.CustomizationGuiRunOnce{CommandList: cmd.runOnce}
+	}
+
+	if cmd.guiLocale != "" || cmd.guiLanguage != "" {
+		return fmt.Errorf("options '-gui-locale' and '-gui-language' are not yet supported")
+	}
+
+	if cmd.hwClockUTC {
+		if isWindows {
+			return fmt.Errorf("option '-hw-clock-utc' is Linux only")
+		}
+		// linprep is guaranteed non-nil here: the guard above rejects
+		// -hw-clock-utc for any identity that is neither Sysprep nor LinuxPrep.
+		utc := true
+		linprep.HwClockUTC = &utc
+	}
+
 	if cmd.tz != "" {
 		if isWindows {
 			tz, err := strconv.ParseInt(cmd.tz, 16, 32)
@@ -234,10 +475,81 @@ func (cmd *customize) Run(ctx context.Context, f *flag.FlagSet) error {
 		}
 	}
 
+	for i, ip6 := range cmd.ip6 {
+		nic := &spec.NicSettingMap[i]
+
+		if nic.MacAddress == "" && i < len(cmd.mac) {
+			nic.MacAddress = cmd.mac[i]
+		}
+
+		gen, err := parseIp6Generator(ip6)
+		if err != nil {
+			return err
+		}
+
+		nic.Adapter.IpV6Spec = &types.CustomizationIPSettingsIpV6AddressSpec{
+			Ip: []types.BaseCustomizationIpV6Generator{gen},
+		}
+
+		if i < len(cmd.gateway6) {
+			nic.Adapter.IpV6Spec.Gateway = strings.Split(cmd.gateway6[i], ",")
+		}
+	}
+
+	if cmd.dump != "" {
+		return dumpCustomizationSpec(spec, cmd.dump)
+	}
+
 	task, err := vm.Customize(ctx, *spec)
 	if err != nil {
 		return err
 	}
 
-	return task.Wait(ctx)
+	if err := task.Wait(ctx); err != nil {
+		return err
+	}
+
+	return cmd.waitForCustomization(ctx, vm)
+}
+
+// waitForCustomization blocks until in-guest customization completes, when
+// -wait is given. It is a no-op otherwise.
+func (cmd *customize) waitForCustomization(ctx context.Context, vm *object.VirtualMachine) error {
+	if !cmd.wait {
+		return nil
+	}
+
+	wctx, cancel := context.WithTimeout(ctx, cmd.timeout)
+	defer cancel()
+
+	return vm.WaitForCustomization(wctx, func(e types.BaseEvent) {
+		fmt.Fprintln(os.Stderr, e.GetEvent().FullFormattedMessage)
+	})
+}
+
+// parseIp6Generator converts a -ip6 flag value into the matching
+// CustomizationIpV6Generator: "dhcp", "slaac" (SLAAC) or a static
+// "address/prefix-length".
+func parseIp6Generator(v string) (types.BaseCustomizationIpV6Generator, error) {
+	switch v {
+	case "dhcp":
+		return new(types.CustomizationDhcpIpV6Generator), nil
+	case "slaac":
+		return new(types.CustomizationStatelessIpV6Generator), nil
+	default:
+		parts := strings.SplitN(v, "/", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid -ip6 value %q, expected dhcp, slaac or address/prefix-length", v)
+		}
+
+		plen, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid -ip6 prefix length in %q: %s", v, err)
+		}
+
+		return &types.CustomizationFixedIpV6{
+			IpAddress:  parts[0],
+			SubnetMask: int32(plen),
+		}, nil
+	}
 }
@@ -0,0 +1,110 @@
+/*
+Copyright (c) 2024 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestCustomizationSpecFileLinuxPrepRoundTrip(t *testing.T) {
+	utc := true
+	want := &types.CustomizationSpec{
+		GlobalIPSettings: types.CustomizationGlobalIPSettings{
+			DnsServerList: []string{"10.0.0.1"},
+			DnsSuffixList: []string{"example.com"},
+		},
+		Identity: &types.CustomizationLinuxPrep{
+			HostName:   &types.CustomizationFixedName{Name: "my-host"},
+			Domain:     "example.com",
+			TimeZone:   "UTC",
+			HwClockUTC: &utc,
+		},
+		NicSettingMap: []types.CustomizationAdapterMapping{
+			{
+				MacAddress: "00:50:56:be:dd:f8",
+				Adapter: types.CustomizationIPSettings{
+					Ip:            &types.CustomizationFixedIp{IpAddress: "10.0.0.178"},
+					SubnetMask:    "255.255.255.0",
+					Gateway:       []string{"10.0.0.1"},
+					DnsServerList: []string{"10.0.0.1"},
+				},
+			},
+		},
+	}
+
+	file := customizationSpecFileFromSpec(want)
+	got, err := file.toSpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round-trip mismatch:\n got=%#v\nwant=%#v", got, want)
+	}
+}
+
+func TestCustomizationSpecFileSysprepRoundTrip(t *testing.T) {
+	want := &types.CustomizationSpec{
+		Identity: &types.CustomizationSysprep{
+			GuiUnattended: types.CustomizationGuiUnattended{
+				TimeZone: 35,
+				Password: &types.CustomizationPassword{Value: "secret", PlainText: true},
+			},
+			UserData: types.CustomizationUserData{
+				ComputerName: &types.CustomizationFixedName{Name: "my-computer"},
+				ProductId:    "XXXXX-XXXXX-XXXXX-XXXXX-XXXXX",
+				FullName:     "Administrator",
+				OrgName:      "Acme",
+			},
+			Identification: types.CustomizationIdentification{
+				JoinWorkgroup: "WORKGROUP",
+			},
+			GuiRunOnce: &types.CustomizationGuiRunOnce{CommandList: []string{"echo hi"}},
+		},
+		NicSettingMap: []types.CustomizationAdapterMapping{},
+	}
+
+	file := customizationSpecFileFromSpec(want)
+	got, err := file.toSpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round-trip mismatch:\n got=%#v\nwant=%#v", got, want)
+	}
+}
+
+func TestCustomizationSpecFileSysprepTextRoundTrip(t *testing.T) {
+	want := &types.CustomizationSpec{
+		Identity:      &types.CustomizationSysprepText{Value: "<answer-file/>"},
+		NicSettingMap: []types.CustomizationAdapterMapping{},
+	}
+
+	file := customizationSpecFileFromSpec(want)
+	got, err := file.toSpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round-trip mismatch:\n got=%#v\nwant=%#v", got, want)
+	}
+}
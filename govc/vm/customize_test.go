@@ -0,0 +1,72 @@
+/*
+Copyright (c) 2019 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestParseIp6Generator(t *testing.T) {
+	tests := []struct {
+		in      string
+		out     types.BaseCustomizationIpV6Generator
+		wantErr bool
+	}{
+		{
+			in:  "dhcp",
+			out: new(types.CustomizationDhcpIpV6Generator),
+		},
+		{
+			in:  "slaac",
+			out: new(types.CustomizationStatelessIpV6Generator),
+		},
+		{
+			in:  "2001:db8::10/64",
+			out: &types.CustomizationFixedIpV6{IpAddress: "2001:db8::10", SubnetMask: 64},
+		},
+		{
+			in:      "2001:db8::10",
+			wantErr: true,
+		},
+		{
+			in:      "2001:db8::10/not-a-number",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		gen, err := parseIp6Generator(test.in)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parseIp6Generator(%q): expected error, got %#v", test.in, gen)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parseIp6Generator(%q): %s", test.in, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(gen, test.out) {
+			t.Errorf("parseIp6Generator(%q) = %#v, want %#v", test.in, gen, test.out)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright (c) 2024 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// SetCloudInitGuestInfo injects a cloud-init NoCloud user-data and meta-data
+// payload into the VM's guestinfo namespace via ExtraConfig, as an
+// alternative to CustomizationSpec based guest customization (GOSC). Both
+// values are gzip compressed and base64 encoded, and the corresponding
+// "guestinfo.userdata.encoding"/"guestinfo.metadata.encoding" keys are set to
+// "gzip+base64" so that cloud-init's VMware GuestInfo datasource decodes them
+// on boot. network-config is not set as a separate guestinfo key: callers
+// that want to supply one embed it in metaData's "network" key instead.
+func (v VirtualMachine) SetCloudInitGuestInfo(ctx context.Context, userData, metaData []byte) (*Task, error) {
+	encodedUserData, err := gzipBase64(userData)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedMetaData, err := gzipBase64(metaData)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := types.VirtualMachineConfigSpec{
+		ExtraConfig: []types.BaseOptionValue{
+			&types.OptionValue{Key: "guestinfo.userdata", Value: encodedUserData},
+			&types.OptionValue{Key: "guestinfo.userdata.encoding", Value: "gzip+base64"},
+			&types.OptionValue{Key: "guestinfo.metadata", Value: encodedMetaData},
+			&types.OptionValue{Key: "guestinfo.metadata.encoding", Value: "gzip+base64"},
+		},
+	}
+
+	task, err := v.Reconfigure(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func gzipBase64(data []byte) (string, error) {
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright (c) 2024 VMware, Inc. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// WaitForCustomization blocks until the VM's in-guest customization (GOSC)
+// reports success or failure, by watching the VM's event stream for a
+// CustomizationSucceeded or CustomizationFailed event. Unlike Task.Wait,
+// which only confirms that vCenter accepted the reconfigure, this confirms
+// that the customization actually ran to completion inside the guest.
+//
+// If given, onEvent is called with each CustomizationStartedEvent,
+// CustomizationLinuxIdentityFailed, CustomizationNetworkSetupFailed and
+// CustomizationSysprepFailed event seen along the way; other event types are
+// not passed to it. This lets a caller surface progress for a
+// stuck customization without opening the vSphere UI. It is called at most
+// once per event and never for the final CustomizationSucceeded/Failed.
+//
+// It returns ctx.Err() if ctx is done before a terminal event is seen.
+func (v VirtualMachine) WaitForCustomization(ctx context.Context, onEvent ...func(types.BaseEvent)) error {
+	m := event.NewManager(v.Client())
+
+	ref := v.Reference()
+	result := make(chan error, 1)
+
+	err := m.Events(ctx, []types.ManagedObjectReference{ref}, 10, true, false, func(events []types.BaseEvent) error {
+		for _, e := range events {
+			switch ev := e.(type) {
+			case *types.CustomizationSucceeded:
+				result <- nil
+				return io.EOF
+			case *types.CustomizationFailed:
+				result <- fmt.Errorf("customization failed: %s", ev.GetEvent().FullFormattedMessage)
+				return io.EOF
+			case *types.CustomizationStartedEvent,
+				*types.CustomizationLinuxIdentityFailed,
+				*types.CustomizationNetworkSetupFailed,
+				*types.CustomizationSysprepFailed:
+				for _, f := range onEvent {
+					f(e)
+				}
+			}
+		}
+		return nil
+	})
+
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}